@@ -6,12 +6,12 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 const (
@@ -41,14 +41,27 @@ type notification struct {
 // Any non-empty string not equal to "0" is considered a notification count,
 // and is displayed as-is.
 type ArgosNotificationServer struct {
-	Host      string
-	Port      int
-	ArgosHome string
+	Host          string
+	Port          int
+	ArgosHome     string
+	Sinks         []string
+	WebhookURLs   []string
+	WebhookSecret string
+	ConfigPath    string
+	AuthToken     string
+	AppSecrets    map[string]string
+	RateLimitIP   float64
+	RateLimitApp  float64
 
 	lastNotificationStatus string
 
 	notificationLock sync.Mutex
 	notifications    map[appname]notification
+
+	dispatchers []*asyncNotifier
+	broadcaster *statusBroadcaster
+	config      *configStore
+	handler     http.Handler
 }
 
 // An ArgosNotificationOption sets one or more configuration parameters on an
@@ -76,6 +89,69 @@ func ArgosHome(home string) ArgosNotificationOption {
 	}
 }
 
+// Sinks sets the notification sink URLs (see NewNotifier) that
+// refreshStatus delivers status changes to. If unset, defaults to a
+// single "argos://" sink writing to ArgosHome.
+func Sinks(sinks ...string) ArgosNotificationOption {
+	return func(s *ArgosNotificationServer) {
+		s.Sinks = sinks
+	}
+}
+
+// WebhookURLs sets the outbound webhook target URLs that receive a signed
+// JSON POST (see WebhookNotifier) whenever the notification status changes.
+func WebhookURLs(urls ...string) ArgosNotificationOption {
+	return func(s *ArgosNotificationServer) {
+		s.WebhookURLs = urls
+	}
+}
+
+// WebhookSecret sets the HMAC secret used to sign outbound webhook payloads
+// via the X-Argos-Signature header.
+func WebhookSecret(secret string) ArgosNotificationOption {
+	return func(s *ArgosNotificationServer) {
+		s.WebhookSecret = secret
+	}
+}
+
+// Config sets the path to a YAML file of per-app icons, priorities,
+// stale-TTLs, mute windows and label rewrites (see configStore). The file
+// is hot-reloaded on SIGHUP. If unset, every app uses the server's
+// built-in defaults.
+func Config(path string) ArgosNotificationOption {
+	return func(s *ArgosNotificationServer) {
+		s.ConfigPath = path
+	}
+}
+
+// AuthToken sets the bearer token required of requests to apps that have
+// no per-app secret configured (see AppSecrets). If unset, those requests
+// are not authenticated.
+func AuthToken(token string) ArgosNotificationOption {
+	return func(s *ArgosNotificationServer) {
+		s.AuthToken = token
+	}
+}
+
+// AppSecrets sets per-app shared HMAC secrets, keyed by the app's URL path
+// (e.g. "hobsons.slack.com"). A request to that path must carry a valid
+// X-Argos-Signature header instead of the global bearer token.
+func AppSecrets(secrets map[string]string) ArgosNotificationOption {
+	return func(s *ArgosNotificationServer) {
+		s.AppSecrets = secrets
+	}
+}
+
+// RateLimits sets the token-bucket rate limits, in requests per second,
+// applied per source IP and per notified app. A limit of 0 disables that
+// limiter.
+func RateLimits(perIP, perApp float64) ArgosNotificationOption {
+	return func(s *ArgosNotificationServer) {
+		s.RateLimitIP = perIP
+		s.RateLimitApp = perApp
+	}
+}
+
 // NewArgosNotificationServer creates a new HTTP server that translates web app unread notification
 // counts into an Argos status badge
 func NewArgosNotificationServer(opts ...ArgosNotificationOption) (*ArgosNotificationServer, error) {
@@ -96,8 +172,39 @@ func (s *ArgosNotificationServer) init() error {
 	if s.Host == "" {
 		s.Host = "localhost"
 	}
+	if len(s.Sinks) == 0 {
+		s.Sinks = []string{"argos://" + s.ArgosHome}
+	}
+	for _, sink := range s.Sinks {
+		notifier, err := NewNotifier(sink)
+		if err != nil {
+			return fmt.Errorf("configuring sink %q: %w", sink, err)
+		}
+		s.dispatchers = append(s.dispatchers, newAsyncNotifier(notifier))
+	}
+	if len(s.WebhookURLs) > 0 {
+		s.dispatchers = append(s.dispatchers, newAsyncNotifier(NewWebhookNotifier(s.WebhookURLs, s.WebhookSecret)))
+	}
+
+	config, err := newConfigStore(s.ConfigPath)
+	if err != nil {
+		return fmt.Errorf("loading app config: %w", err)
+	}
+	s.config = config
+
 	s.notifications = map[appname]notification{}
 	s.lastNotificationStatus = impossibleNotificationStatus
+	s.broadcaster = newStatusBroadcaster()
+
+	s.handler = chainMiddleware(
+		http.HandlerFunc(s.routeRequest),
+		loggingMiddleware(),
+		authMiddleware(s.AuthToken, s.AppSecrets),
+		rateLimitMiddleware(
+			newRateLimiter(s.RateLimitIP, s.RateLimitIP*2+1),
+			newRateLimiter(s.RateLimitApp, s.RateLimitApp*2+1)),
+		metricsMiddleware(),
+	)
 	return nil
 }
 
@@ -106,7 +213,30 @@ func (s *ArgosNotificationServer) ListenAddr() string {
 	return fmt.Sprintf("%s:%d", s.Host, s.Port)
 }
 
+// ServeHTTP handles every request through the auth, rate-limiting and
+// access-logging middleware chain before reaching routeRequest.
 func (s *ArgosNotificationServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+func (s *ArgosNotificationServer) routeRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		switch r.URL.Path {
+		case "/events":
+			s.serveEvents(w, r)
+			return
+		case "/status":
+			s.serveStatus(w, r)
+			return
+		case "/metrics":
+			promhttp.Handler().ServeHTTP(w, r)
+			return
+		case "/healthz":
+			s.serveHealthz(w, r)
+			return
+		}
+	}
+
 	notifyingApp, err := requestURLNotificationApp(r.URL.Path)
 	if err != nil {
 		log.Println("Request to invalid path", r.URL.Path, ":", err)
@@ -136,58 +266,135 @@ func requestURLNotificationApp(path string) (string, error) {
 
 func (s *ArgosNotificationServer) updateArgosStatus(app, notifications string) {
 	s.notificationLock.Lock()
-	defer s.notificationLock.Unlock()
-
 	s.notifications[appname(app)] = notification{
 		label:     notifications,
 		updatedAt: time.Now(),
 	}
+	s.notificationLock.Unlock()
+
+	recordNotificationMetrics(appname(app), notifications)
+	s.refreshStatus()
 }
 
-// PushArgosStatus periodically writes the notification status to
-// [ArgosHome]/.notification.status
+// PushArgosStatus periodically prunes stale notifications. Status changes
+// caused by an incoming notification are pushed to sinks and stream
+// subscribers immediately (see refreshStatus); changes that come from the
+// clock or a config reload, such as a mute window opening or closing, are
+// picked up on this polling cadence.
 func (s *ArgosNotificationServer) PushArgosStatus() {
 	for {
 		s.pruneStaleNotifications()
-		if err := s.writeNotificationStatus(); err != nil {
-			log.Println("error writing notification status:", err)
-		}
+		s.refreshStatus()
 		time.Sleep(4700 * time.Millisecond)
 	}
 }
 
-func (s *ArgosNotificationServer) argosNotificationFilepath() string {
-	return filepath.Join(s.ArgosHome, ".notifications")
+// refreshStatus recomputes the notification status and, if it changed,
+// dispatches the update to every registered sink and live stream
+// subscriber. Sink delivery happens asynchronously (see asyncNotifier), so
+// a slow or hung sink never blocks the caller — refreshStatus runs
+// synchronously on the request goroutine via updateArgosStatus.
+func (s *ArgosNotificationServer) refreshStatus() {
+	notificationStatus := s.NotificationStatus()
+
+	s.notificationLock.Lock()
+	changed := notificationStatus != s.lastNotificationStatus
+	if changed {
+		s.lastNotificationStatus = notificationStatus
+	}
+	s.notificationLock.Unlock()
+
+	if !changed {
+		statusWritesSkippedTotal.Inc()
+		return
+	}
+	statusWritesTotal.Inc()
+
+	apps := s.AppStatuses()
+	for _, dispatcher := range s.dispatchers {
+		dispatcher.Dispatch(notificationStatus, apps)
+	}
+
+	if msg := marshalStatusEvent(notificationStatus, apps); msg != nil {
+		s.broadcaster.Broadcast(msg)
+	}
 }
 
-func (s *ArgosNotificationServer) argosNotificationTempFilepath() string {
-	return filepath.Join(s.ArgosHome, ".notifications.tmp")
+// statusEvent is the JSON shape streamed to /events subscribers and
+// returned by GET /status.
+type statusEvent struct {
+	Status string      `json:"status"`
+	Apps   []AppStatus `json:"apps"`
 }
 
-func (s *ArgosNotificationServer) writeNotificationStatus() error {
-	notificationStatus := s.NotificationStatus()
-	if notificationStatus == s.lastNotificationStatus {
+func marshalStatusEvent(status string, apps []AppStatus) []byte {
+	msg, err := json.Marshal(statusEvent{Status: status, Apps: apps})
+	if err != nil {
+		log.Println("error marshaling status event:", err)
 		return nil
 	}
-	s.lastNotificationStatus = notificationStatus
+	return msg
+}
 
-	tempFilePath, err := s.writeNotificationTempFile(notificationStatus)
-	if err != nil {
-		return err
-	}
-	return os.Rename(tempFilePath, s.argosNotificationFilepath())
+// serveStatus handles GET /status, returning the current per-app
+// notification state as JSON.
+func (s *ArgosNotificationServer) serveStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(marshalStatusEvent(s.NotificationStatus(), s.AppStatuses()))
 }
 
-func (s *ArgosNotificationServer) writeNotificationTempFile(status string) (tmpFilePath string, err error) {
-	tmpFilePath = s.argosNotificationTempFilepath()
-	statusFH, err := os.Create(tmpFilePath)
-	if err != nil {
-		return tmpFilePath, err
+// serveEvents handles GET /events, streaming every notification status
+// change to the client as Server-Sent Events in real time.
+func (s *ArgosNotificationServer) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
 	}
-	defer statusFH.Close()
 
-	_, err = fmt.Fprintln(statusFH, status)
-	return tmpFilePath, err
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch, unsubscribe := s.broadcaster.Subscribe()
+	defer unsubscribe()
+
+	fmt.Fprintf(w, "data: %s\n\n", marshalStatusEvent(s.NotificationStatus(), s.AppStatuses()))
+	flusher.Flush()
+
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", msg)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// AppStatus describes a single app's notification state, for consumers
+// (such as outbound webhooks) that need more than the flattened badge
+// string.
+type AppStatus struct {
+	App       string    `json:"app"`
+	Label     string    `json:"label"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// AppStatuses returns a snapshot of the current per-app notification state.
+func (s *ArgosNotificationServer) AppStatuses() []AppStatus {
+	s.notificationLock.Lock()
+	defer s.notificationLock.Unlock()
+
+	apps := make([]AppStatus, 0, len(s.notifications))
+	for app, notifications := range s.notifications {
+		apps = append(apps, AppStatus{App: string(app), Label: notifications.label, UpdatedAt: notifications.updatedAt})
+	}
+	return apps
 }
 
 // NotificationStatus returns the current notification status
@@ -195,38 +402,60 @@ func (s *ArgosNotificationServer) NotificationStatus() string {
 	s.notificationLock.Lock()
 	defer s.notificationLock.Unlock()
 
-	var visibleNotifications []string
+	type visibleApp struct {
+		app      appname
+		display  string
+		priority int
+	}
+
+	var visible []visibleApp
 	for app, notifications := range s.notifications {
-		if appDisplay := s.notificationDisplay(app, notifications); appDisplay != "" {
-			visibleNotifications = append(visibleNotifications, appDisplay)
+		if display := s.notificationDisplay(app, notifications); display != "" {
+			visible = append(visible, visibleApp{app: app, display: display, priority: s.config.Priority(app)})
 		}
 	}
-	sort.Strings(visibleNotifications)
-	return strings.Join(visibleNotifications, " ")
+
+	sort.Slice(visible, func(i, j int) bool {
+		if visible[i].priority != visible[j].priority {
+			return visible[i].priority > visible[j].priority
+		}
+		return visible[i].app < visible[j].app
+	})
+
+	displays := make([]string, len(visible))
+	for i, v := range visible {
+		displays[i] = v.display
+	}
+	return strings.Join(displays, " ")
 }
 
 func (s *ArgosNotificationServer) notificationDisplay(app appname, notifications notification) string {
-	if notifications.label == "" || notifications.label == "0" {
+	label := s.config.RewriteLabel(app, notifications.label)
+	if label == "" || label == "0" {
+		return ""
+	}
+	if s.config.Muted(app, time.Now()) {
 		return ""
 	}
 
 	appIcon := appDisplayIcons[app]
+	if icon, ok := s.config.Icon(app); ok {
+		appIcon = icon
+	}
 	if appIcon == "" {
 		appIcon = unknownAppIcon
 	}
-	if notifications.label == "1" {
+	if label == "1" {
 		return appIcon
 	}
-	return fmt.Sprint(appIcon, notifications.label)
+	return fmt.Sprint(appIcon, label)
 }
 
 func (s *ArgosNotificationServer) pruneStaleNotifications() {
 	s.notificationLock.Lock()
-	defer s.notificationLock.Unlock()
-
 	var defunctApps []appname
 	for app, notifications := range s.notifications {
-		if time.Since(notifications.updatedAt) > notificationMaxLifetime {
+		if time.Since(notifications.updatedAt) > s.config.StaleAfter(app, notificationMaxLifetime) {
 			defunctApps = append(defunctApps, app)
 		}
 	}
@@ -234,4 +463,13 @@ func (s *ArgosNotificationServer) pruneStaleNotifications() {
 	for _, app := range defunctApps {
 		delete(s.notifications, app)
 	}
+	s.notificationLock.Unlock()
+
+	if len(defunctApps) > 0 {
+		prunedStaleTotal.Add(float64(len(defunctApps)))
+		for _, app := range defunctApps {
+			notificationLabelValue.DeleteLabelValues(appMetricLabels.normalize(string(app)))
+		}
+		s.refreshStatus()
+	}
 }
@@ -0,0 +1,46 @@
+package main
+
+import "sync"
+
+// statusBroadcaster fans out notification status updates to any number of
+// live subscribers (GET /events SSE clients) the moment they happen,
+// instead of subscribers having to poll a file.
+type statusBroadcaster struct {
+	mu          sync.Mutex
+	subscribers map[chan []byte]struct{}
+}
+
+func newStatusBroadcaster() *statusBroadcaster {
+	return &statusBroadcaster{subscribers: map[chan []byte]struct{}{}}
+}
+
+// Subscribe registers a new subscriber channel and returns it along with an
+// unsubscribe function the caller must invoke when done listening.
+func (b *statusBroadcaster) Subscribe() (ch chan []byte, unsubscribe func()) {
+	ch = make(chan []byte, 16)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, ch)
+		close(ch)
+		b.mu.Unlock()
+	}
+}
+
+// Broadcast sends msg to every live subscriber. A subscriber that isn't
+// keeping up has the message dropped rather than blocking the others.
+func (b *statusBroadcaster) Broadcast(msg []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
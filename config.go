@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"regexp"
+	"sync"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MuteWindow is a time-of-day range, in "15:04" format, during which an
+// app's label is suppressed from the badge. A window where From is after
+// To spans midnight, e.g. {From: "22:00", To: "06:00"}.
+type MuteWindow struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+const muteWindowTimeFormat = "15:04"
+
+func (w MuteWindow) active(now time.Time) bool {
+	from, err := time.Parse(muteWindowTimeFormat, w.From)
+	if err != nil {
+		return false
+	}
+	to, err := time.Parse(muteWindowTimeFormat, w.To)
+	if err != nil {
+		return false
+	}
+
+	clock := time.Date(0, 1, 1, now.Hour(), now.Minute(), 0, 0, time.UTC)
+	from = time.Date(0, 1, 1, from.Hour(), from.Minute(), 0, 0, time.UTC)
+	to = time.Date(0, 1, 1, to.Hour(), to.Minute(), 0, 0, time.UTC)
+
+	if from.Before(to) {
+		return !clock.Before(from) && clock.Before(to)
+	}
+	return !clock.Before(from) || clock.Before(to)
+}
+
+// AppConfigEntry describes the configurable behaviour for a single app:
+// its badge icon, its sort priority, a stale-TTL override, mute windows
+// during which its label is suppressed, and an optional regex rewrite
+// applied to the incoming label.
+type AppConfigEntry struct {
+	Icon       string        `yaml:"icon"`
+	Priority   int           `yaml:"priority"`
+	StaleAfter time.Duration `yaml:"staleAfter"`
+	Mute       []MuteWindow  `yaml:"mute"`
+	Rewrite    string        `yaml:"rewrite"`
+
+	rewrite *regexp.Regexp
+}
+
+// AppConfig is the parsed contents of the --config file, keyed by app name.
+type AppConfig struct {
+	Apps map[appname]*AppConfigEntry `yaml:"apps"`
+}
+
+// configStore holds the most recently loaded AppConfig and reloads it from
+// disk on SIGHUP, so operators can edit apps.yaml without restarting the
+// relay.
+type configStore struct {
+	path string
+
+	mu  sync.RWMutex
+	cfg *AppConfig
+}
+
+// newConfigStore loads path (if non-empty) and starts watching for SIGHUP
+// to reload it. An empty path is valid and yields a store with no entries,
+// so every app falls back to the server's built-in defaults.
+func newConfigStore(path string) (*configStore, error) {
+	s := &configStore{path: path, cfg: &AppConfig{Apps: map[appname]*AppConfigEntry{}}}
+	if path == "" {
+		return s, nil
+	}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	s.watchSIGHUP()
+	return s, nil
+}
+
+func (s *configStore) reload() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("reading config %s: %w", s.path, err)
+	}
+
+	cfg := &AppConfig{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("parsing config %s: %w", s.path, err)
+	}
+	for app, entry := range cfg.Apps {
+		if entry.Rewrite == "" {
+			continue
+		}
+		re, err := regexp.Compile(entry.Rewrite)
+		if err != nil {
+			return fmt.Errorf("config: app %s: invalid rewrite regex: %w", app, err)
+		}
+		entry.rewrite = re
+	}
+
+	s.mu.Lock()
+	s.cfg = cfg
+	s.mu.Unlock()
+
+	log.Println("loaded app config from", s.path)
+	return nil
+}
+
+func (s *configStore) watchSIGHUP() {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	go func() {
+		for range sighup {
+			if err := s.reload(); err != nil {
+				log.Println("error reloading config:", err)
+			}
+		}
+	}()
+}
+
+func (s *configStore) entry(app appname) *AppConfigEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg.Apps[app]
+}
+
+// Icon returns the configured icon for app, if any.
+func (s *configStore) Icon(app appname) (string, bool) {
+	if e := s.entry(app); e != nil && e.Icon != "" {
+		return e.Icon, true
+	}
+	return "", false
+}
+
+// Priority returns the configured display priority for app, defaulting to 0.
+func (s *configStore) Priority(app appname) int {
+	if e := s.entry(app); e != nil {
+		return e.Priority
+	}
+	return 0
+}
+
+// StaleAfter returns the configured stale-TTL for app, or def if unset.
+func (s *configStore) StaleAfter(app appname, def time.Duration) time.Duration {
+	if e := s.entry(app); e != nil && e.StaleAfter > 0 {
+		return e.StaleAfter
+	}
+	return def
+}
+
+// Muted reports whether app's label should be suppressed from the badge at
+// the given instant.
+func (s *configStore) Muted(app appname, now time.Time) bool {
+	e := s.entry(app)
+	if e == nil {
+		return false
+	}
+	for _, window := range e.Mute {
+		if window.active(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// RewriteLabel applies app's configured rewrite regex to label, returning
+// the first capture group if the regex matches, or label unchanged
+// otherwise.
+func (s *configStore) RewriteLabel(app appname, label string) string {
+	e := s.entry(app)
+	if e == nil || e.rewrite == nil {
+		return label
+	}
+	if match := e.rewrite.FindStringSubmatch(label); len(match) > 1 {
+		return match[1]
+	}
+	return label
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMuteWindowActive(t *testing.T) {
+	cases := []struct {
+		name   string
+		window MuteWindow
+		now    time.Time
+		want   bool
+	}{
+		{
+			name:   "within same-day window",
+			window: MuteWindow{From: "09:00", To: "17:00"},
+			now:    time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			want:   true,
+		},
+		{
+			name:   "before same-day window",
+			window: MuteWindow{From: "09:00", To: "17:00"},
+			now:    time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC),
+			want:   false,
+		},
+		{
+			name:   "after same-day window",
+			window: MuteWindow{From: "09:00", To: "17:00"},
+			now:    time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC),
+			want:   false,
+		},
+		{
+			name:   "within overnight window, late side",
+			window: MuteWindow{From: "22:00", To: "06:00"},
+			now:    time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC),
+			want:   true,
+		},
+		{
+			name:   "within overnight window, early side",
+			window: MuteWindow{From: "22:00", To: "06:00"},
+			now:    time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC),
+			want:   true,
+		},
+		{
+			name:   "outside overnight window",
+			window: MuteWindow{From: "22:00", To: "06:00"},
+			now:    time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			want:   false,
+		},
+		{
+			name:   "invalid time format never active",
+			window: MuteWindow{From: "not-a-time", To: "06:00"},
+			now:    time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC),
+			want:   false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.window.active(c.now); got != c.want {
+				t.Errorf("active() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestNewConfigStoreEmptyPath(t *testing.T) {
+	store, err := newConfigStore("")
+	if err != nil {
+		t.Fatalf("newConfigStore(\"\") error: %v", err)
+	}
+	if store == nil {
+		t.Fatal("newConfigStore(\"\") returned nil store")
+	}
+
+	if icon, ok := store.Icon("anything"); ok || icon != "" {
+		t.Errorf("Icon() = %q, %v; want \"\", false", icon, ok)
+	}
+	if priority := store.Priority("anything"); priority != 0 {
+		t.Errorf("Priority() = %d, want 0", priority)
+	}
+	if got := store.StaleAfter("anything", time.Minute); got != time.Minute {
+		t.Errorf("StaleAfter() = %v, want %v", got, time.Minute)
+	}
+	if store.Muted("anything", time.Now()) {
+		t.Error("Muted() = true, want false for unconfigured app")
+	}
+	if got := store.RewriteLabel("anything", "42"); got != "42" {
+		t.Errorf("RewriteLabel() = %q, want unchanged %q", got, "42")
+	}
+}
+
+func TestNewConfigStoreLoadsFile(t *testing.T) {
+	path := t.TempDir() + "/apps.yaml"
+	const contents = `
+apps:
+  hobsons.slack.com:
+    icon: "@"
+    priority: 5
+    staleAfter: 1h
+    rewrite: "^(\\d+) unread$"
+    mute:
+      - from: "22:00"
+        to: "06:00"
+`
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := newConfigStore(path)
+	if err != nil {
+		t.Fatalf("newConfigStore() error: %v", err)
+	}
+
+	if icon, ok := store.Icon("hobsons.slack.com"); !ok || icon != "@" {
+		t.Errorf("Icon() = %q, %v; want \"@\", true", icon, ok)
+	}
+	if priority := store.Priority("hobsons.slack.com"); priority != 5 {
+		t.Errorf("Priority() = %d, want 5", priority)
+	}
+	if got := store.StaleAfter("hobsons.slack.com", time.Minute); got != time.Hour {
+		t.Errorf("StaleAfter() = %v, want %v", got, time.Hour)
+	}
+	if got := store.RewriteLabel("hobsons.slack.com", "12 unread"); got != "12" {
+		t.Errorf("RewriteLabel() = %q, want %q", got, "12")
+	}
+	if got := store.RewriteLabel("hobsons.slack.com", "garbage"); got != "garbage" {
+		t.Errorf("RewriteLabel() with no match = %q, want unchanged %q", got, "garbage")
+	}
+
+	overnight := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+	if !store.Muted("hobsons.slack.com", overnight) {
+		t.Error("Muted() = false, want true during configured mute window")
+	}
+	midday := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	if store.Muted("hobsons.slack.com", midday) {
+		t.Error("Muted() = true, want false outside configured mute window")
+	}
+}
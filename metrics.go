@@ -0,0 +1,144 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	notificationsReceivedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "argos_notifications_received_total",
+		Help: "Total number of notification updates received, labelled by app.",
+	}, []string{"app"})
+
+	notificationLabelValue = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "argos_notification_label_value",
+		Help: "Current notification label for each app, as a number (non-numeric labels report 1, empty/\"0\" reports 0).",
+	}, []string{"app"})
+
+	prunedStaleTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "argos_pruned_stale_total",
+		Help: "Total number of notifications pruned for being stale.",
+	})
+
+	statusWritesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "argos_status_writes_total",
+		Help: "Total number of times the notification status changed and was pushed to sinks.",
+	})
+
+	statusWritesSkippedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "argos_status_writes_skipped_total",
+		Help: "Total number of times the notification status was recomputed but unchanged, so the write was skipped.",
+	})
+
+	sinkWriteErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "argos_sink_write_errors_total",
+		Help: "Total number of sink delivery errors, labelled by sink type.",
+	}, []string{"sink"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "argos_http_request_duration_seconds",
+		Help:    "HTTP handler latency in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "path", "status"})
+)
+
+// maxTrackedLabelValues bounds how many distinct values a boundedLabelSet
+// will track before folding further distinct values into its fallback
+// label, so attacker-controlled input (an app name or URL path) can't
+// explode Prometheus label cardinality without bound.
+const maxTrackedLabelValues = 200
+
+// boundedLabelSet caps the number of distinct Prometheus label values
+// derived from unauthenticated, attacker-controlled input. Once the cap is
+// reached, further distinct values normalize to fallback instead of each
+// creating a new time series.
+type boundedLabelSet struct {
+	fallback string
+
+	mu    sync.Mutex
+	known map[string]struct{}
+}
+
+func newBoundedLabelSet(fallback string) *boundedLabelSet {
+	return &boundedLabelSet{fallback: fallback, known: map[string]struct{}{}}
+}
+
+// normalize returns value unchanged if it's already tracked or there's
+// still room to track it, otherwise it returns the fallback label.
+func (b *boundedLabelSet) normalize(value string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.known[value]; ok {
+		return value
+	}
+	if len(b.known) >= maxTrackedLabelValues {
+		return b.fallback
+	}
+	b.known[value] = struct{}{}
+	return value
+}
+
+var (
+	appMetricLabels  = newBoundedLabelSet("other")
+	pathMetricLabels = newBoundedLabelSet("other")
+)
+
+// recordNotificationMetrics updates the received-count and label-value
+// metrics for a single incoming notification update.
+func recordNotificationMetrics(app appname, label string) {
+	appLabel := appMetricLabels.normalize(string(app))
+	notificationsReceivedTotal.WithLabelValues(appLabel).Inc()
+
+	value := 1.0
+	switch {
+	case label == "" || label == "0":
+		value = 0
+	default:
+		if parsed, err := strconv.ParseFloat(label, 64); err == nil {
+			value = parsed
+		}
+	}
+	notificationLabelValue.WithLabelValues(appLabel).Set(value)
+}
+
+// sinkName returns a label identifying notifier's sink type for metrics,
+// unwrapping a retryingNotifier to the type it wraps.
+func sinkName(n Notifier) string {
+	if named, ok := n.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return "unknown"
+}
+
+// metricsMiddleware observes HTTP handler latency into
+// httpRequestDuration, labelled by method, path and response status. The
+// path label is folded through pathMetricLabels, so it sits after auth and
+// rate limiting in the chain and still can't be used to explode label
+// cardinality even when no bound on the request path itself is possible.
+func metricsMiddleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(rec, r)
+
+			httpRequestDuration.
+				WithLabelValues(r.Method, pathMetricLabels.normalize(r.URL.Path), strconv.Itoa(rec.status)).
+				Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// serveHealthz handles GET /healthz, a liveness probe endpoint.
+func (s *ArgosNotificationServer) serveHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"ok"}`))
+}
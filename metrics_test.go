@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestBoundedLabelSetNormalize(t *testing.T) {
+	set := newBoundedLabelSet("other")
+
+	for i := 0; i < maxTrackedLabelValues; i++ {
+		value := string(rune('a' + i%26))
+		set.normalize(value + string(rune(i)))
+	}
+
+	if got := set.normalize("brand-new-value"); got != "other" {
+		t.Errorf("normalize() past capacity = %q, want fallback %q", got, "other")
+	}
+}
+
+func TestBoundedLabelSetTracksUnderCapacity(t *testing.T) {
+	set := newBoundedLabelSet("other")
+
+	if got := set.normalize("hobsons.slack.com"); got != "hobsons.slack.com" {
+		t.Errorf("normalize() = %q, want unchanged %q", got, "hobsons.slack.com")
+	}
+	// Seeing the same value again should still return itself, not fallback.
+	if got := set.normalize("hobsons.slack.com"); got != "hobsons.slack.com" {
+		t.Errorf("normalize() on repeat = %q, want unchanged %q", got, "hobsons.slack.com")
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Middleware wraps an http.Handler with additional behaviour.
+type Middleware func(http.Handler) http.Handler
+
+// chainMiddleware composes middlewares around handler so the first one
+// listed runs outermost: first to see the request, last to see the
+// response.
+func chainMiddleware(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func notifyingAppOf(r *http.Request) string {
+	return strings.TrimPrefix(r.URL.Path, "/")
+}
+
+// authMiddleware rejects requests that don't carry valid credentials. A
+// request to an app with a configured per-app secret must carry a valid
+// X-Argos-Signature HMAC of its body; otherwise, if globalToken is set, the
+// request must carry a matching "Authorization: Bearer <token>" header.
+func authMiddleware(globalToken string, appSecrets map[string]string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if secret, ok := appSecrets[notifyingAppOf(r)]; ok {
+				if !validSignature(r, secret) {
+					http.Error(w, "invalid signature", http.StatusUnauthorized)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if globalToken != "" && !validBearerToken(r, globalToken) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func validBearerToken(r *http.Request, token string) bool {
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, prefix) {
+		return false
+	}
+	return hmac.Equal([]byte(strings.TrimPrefix(header, prefix)), []byte(token))
+}
+
+func validSignature(r *http.Request, secret string) bool {
+	signature := r.Header.Get("X-Argos-Signature")
+	if signature == "" {
+		return false
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(signature), []byte(expected))
+}
+
+// tokenBucket is a simple token-bucket rate limiter for a single key.
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// rateLimiterIdleTTL is how long a key's bucket may sit unused before
+// sweepIdleBuckets reclaims it.
+const rateLimiterIdleTTL = 10 * time.Minute
+
+// rateLimiterSweepPeriod is how often sweepIdleBuckets runs.
+const rateLimiterSweepPeriod = time.Minute
+
+// rateLimiterMaxBuckets caps how many distinct keys a rateLimiter will
+// track at once, so an unauthenticated caller varying the request path (or
+// spoofing source IPs) can't grow the bucket map without bound between
+// sweeps.
+const rateLimiterMaxBuckets = 10000
+
+// rateLimiter holds one tokenBucket per key (source IP or app name). Idle
+// buckets are reclaimed by a background sweep, and the map is capped at
+// rateLimiterMaxBuckets so an unbounded set of keys can't exhaust memory.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// newRateLimiter creates a rateLimiter allowing ratePerSecond requests per
+// key on average, up to burst in a spike. A ratePerSecond of 0 or less
+// disables the limiter (Allow always returns true).
+func newRateLimiter(ratePerSecond, burst float64) *rateLimiter {
+	if ratePerSecond <= 0 {
+		return nil
+	}
+	l := &rateLimiter{rate: ratePerSecond, burst: burst, buckets: map[string]*tokenBucket{}}
+	go l.sweepLoop()
+	return l
+}
+
+// sweepLoop periodically reclaims idle buckets for the lifetime of the
+// process; rateLimiters are never torn down, so this never needs to stop.
+func (l *rateLimiter) sweepLoop() {
+	for range time.Tick(rateLimiterSweepPeriod) {
+		l.sweepIdleBuckets()
+	}
+}
+
+// sweepIdleBuckets deletes buckets that haven't been touched in
+// rateLimiterIdleTTL.
+func (l *rateLimiter) sweepIdleBuckets() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.updatedAt) > rateLimiterIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Allow reports whether a request for key is allowed, consuming one token
+// if so. A nil *rateLimiter always allows. Once the bucket map is at
+// rateLimiterMaxBuckets capacity, requests for keys with no existing
+// bucket are rejected rather than growing the map further.
+func (l *rateLimiter) Allow(key string) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= rateLimiterMaxBuckets {
+			return false
+		}
+		bucket = &tokenBucket{tokens: l.burst, updatedAt: time.Now()}
+		l.buckets[key] = bucket
+	}
+
+	now := time.Now()
+	bucket.tokens += now.Sub(bucket.updatedAt).Seconds() * l.rate
+	if bucket.tokens > l.burst {
+		bucket.tokens = l.burst
+	}
+	bucket.updatedAt = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// rateLimitMiddleware rejects requests once perIP or perApp's token bucket
+// for the request's source IP or notified app is exhausted.
+func rateLimitMiddleware(perIP, perApp *rateLimiter) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !perIP.Allow(clientIP(r)) || !perApp.Allow(notifyingAppOf(r)) {
+				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// statusRecorder captures the status code written by the wrapped handler
+// so loggingMiddleware can report it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// accessLogEntry is the structured JSON access log line emitted by
+// loggingMiddleware for every request.
+type accessLogEntry struct {
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	Remote    string  `json:"remote"`
+	App       string  `json:"app,omitempty"`
+	Status    int     `json:"status"`
+	LatencyMS float64 `json:"latencyMs"`
+}
+
+// loggingMiddleware emits one structured JSON access log line per request.
+func loggingMiddleware() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+			next.ServeHTTP(rec, r)
+
+			entry := accessLogEntry{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Remote:    clientIP(r),
+				App:       notifyingAppOf(r),
+				Status:    rec.status,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if line, err := json.Marshal(entry); err == nil {
+				log.Println(string(line))
+			}
+		})
+	}
+}
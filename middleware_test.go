@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidBearerToken(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/hobsons.slack.com", nil)
+	req.Header.Set("Authorization", "Bearer s3cr3t")
+
+	if !validBearerToken(req, "s3cr3t") {
+		t.Error("validBearerToken() = false, want true for matching token")
+	}
+	if validBearerToken(req, "wrong") {
+		t.Error("validBearerToken() = true, want false for mismatched token")
+	}
+
+	noHeader := httptest.NewRequest(http.MethodPost, "/hobsons.slack.com", nil)
+	if validBearerToken(noHeader, "s3cr3t") {
+		t.Error("validBearerToken() = true, want false with no Authorization header")
+	}
+}
+
+func TestValidSignature(t *testing.T) {
+	body := []byte(`{"label":"3"}`)
+	mac := hmac.New(sha256.New, []byte("topsecret"))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/hobsons.slack.com", bytes.NewReader(body))
+	req.Header.Set("X-Argos-Signature", signature)
+
+	if !validSignature(req, "topsecret") {
+		t.Error("validSignature() = false, want true for matching HMAC")
+	}
+
+	req2 := httptest.NewRequest(http.MethodPost, "/hobsons.slack.com", bytes.NewReader(body))
+	req2.Header.Set("X-Argos-Signature", signature)
+	if validSignature(req2, "wrongsecret") {
+		t.Error("validSignature() = true, want false for mismatched secret")
+	}
+
+	req3 := httptest.NewRequest(http.MethodPost, "/hobsons.slack.com", bytes.NewReader(body))
+	if validSignature(req3, "topsecret") {
+		t.Error("validSignature() = true, want false with no signature header")
+	}
+}
+
+func TestValidSignaturePreservesBody(t *testing.T) {
+	body := []byte(`{"label":"3"}`)
+	mac := hmac.New(sha256.New, []byte("topsecret"))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/hobsons.slack.com", bytes.NewReader(body))
+	req.Header.Set("X-Argos-Signature", signature)
+
+	if !validSignature(req, "topsecret") {
+		t.Fatal("validSignature() = false, want true")
+	}
+
+	replayed, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("reading body after validSignature: %v", err)
+	}
+	if !bytes.Equal(replayed, body) {
+		t.Errorf("body after validSignature = %q, want %q", replayed, body)
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	limiter := newRateLimiter(1, 2)
+
+	if !limiter.Allow("a") {
+		t.Fatal("first request should be allowed (burst)")
+	}
+	if !limiter.Allow("a") {
+		t.Fatal("second request should be allowed (within burst)")
+	}
+	if limiter.Allow("a") {
+		t.Fatal("third immediate request should be rejected once burst is exhausted")
+	}
+
+	if !limiter.Allow("b") {
+		t.Error("a different key should have its own independent bucket")
+	}
+}
+
+func TestRateLimiterNilAlwaysAllows(t *testing.T) {
+	var limiter *rateLimiter
+	for i := 0; i < 5; i++ {
+		if !limiter.Allow("anything") {
+			t.Fatal("nil rateLimiter should always allow")
+		}
+	}
+}
+
+func TestNewRateLimiterDisabled(t *testing.T) {
+	if newRateLimiter(0, 10) != nil {
+		t.Error("newRateLimiter(0, ...) should return nil to disable the limiter")
+	}
+	if newRateLimiter(-1, 10) != nil {
+		t.Error("newRateLimiter(negative, ...) should return nil to disable the limiter")
+	}
+}
+
+func TestRateLimiterSweepIdleBuckets(t *testing.T) {
+	limiter := newRateLimiter(1, 2)
+	limiter.Allow("stale-key")
+
+	limiter.mu.Lock()
+	limiter.buckets["stale-key"].updatedAt = limiter.buckets["stale-key"].updatedAt.Add(-2 * rateLimiterIdleTTL)
+	limiter.mu.Unlock()
+
+	limiter.sweepIdleBuckets()
+
+	limiter.mu.Lock()
+	_, stillPresent := limiter.buckets["stale-key"]
+	limiter.mu.Unlock()
+	if stillPresent {
+		t.Error("sweepIdleBuckets() left an idle bucket in place")
+	}
+}
+
+func TestRateLimiterMaxBucketsCap(t *testing.T) {
+	limiter := newRateLimiter(1, 1)
+	limiter.mu.Lock()
+	for i := 0; i < rateLimiterMaxBuckets; i++ {
+		limiter.buckets[string(rune(i))] = &tokenBucket{tokens: 1}
+	}
+	limiter.mu.Unlock()
+
+	if limiter.Allow("one-key-too-many") {
+		t.Error("Allow() for a new key should be rejected once the bucket map is at capacity")
+	}
+}
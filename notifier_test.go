@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/url"
+	"os/user"
+	"path/filepath"
+	"testing"
+)
+
+func TestSinkPathPlain(t *testing.T) {
+	u, err := url.Parse("file:///tmp/badge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sinkPath(u), "/tmp/badge"; got != want {
+		t.Errorf("sinkPath() = %q, want %q", got, want)
+	}
+}
+
+func TestSinkPathHostAndPath(t *testing.T) {
+	u, err := url.Parse("file://host/path")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sinkPath(u), "/host/path"; got != want {
+		t.Errorf("sinkPath() = %q, want %q", got, want)
+	}
+}
+
+func TestSinkPathHomeExpansion(t *testing.T) {
+	u, err := url.Parse("argos:///~/.config/argos")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := user.Current()
+	if err != nil {
+		t.Skip("cannot determine current user home directory:", err)
+	}
+	want := filepath.Join(current.HomeDir, ".config/argos")
+
+	if got := sinkPath(u); got != want {
+		t.Errorf("sinkPath() = %q, want %q", got, want)
+	}
+}
+
+func TestNewNotifierSchemes(t *testing.T) {
+	dir := t.TempDir()
+
+	cases := []struct {
+		name     string
+		sinkURL  string
+		wantSink string
+		wantErr  bool
+	}{
+		{name: "argos", sinkURL: "argos://" + dir, wantSink: "argos"},
+		{name: "file", sinkURL: "file://" + dir + "/badge", wantSink: "file"},
+		{name: "exec", sinkURL: "exec://" + dir + "/script.sh", wantSink: "exec"},
+		{name: "http", sinkURL: "http://example.invalid/hook", wantSink: "http"},
+		{name: "https", sinkURL: "https://example.invalid/hook", wantSink: "http"},
+		{name: "smtp", sinkURL: "smtp://example.invalid/?to=a@b.com", wantSink: "smtp"},
+		{name: "smtp missing to", sinkURL: "smtp://example.invalid/", wantErr: true},
+		{name: "unsupported scheme", sinkURL: "ftp://example.invalid/", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			notifier, err := NewNotifier(c.sinkURL)
+			if c.wantErr {
+				if err == nil {
+					t.Fatal("NewNotifier() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewNotifier() error: %v", err)
+			}
+			if got := sinkName(notifier); got != c.wantSink {
+				t.Errorf("sinkName() = %q, want %q", got, c.wantSink)
+			}
+		})
+	}
+}
+
+func TestNewNotifierInvalidURL(t *testing.T) {
+	if _, err := NewNotifier("://not-a-valid-url"); err == nil {
+		t.Error("NewNotifier() error = nil, want an error for an unparseable URL")
+	}
+}
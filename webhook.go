@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const webhookQueueSize = 100
+
+// webhookPayload is the JSON body POSTed to webhook targets on every
+// notification status change.
+type webhookPayload struct {
+	Status string      `json:"status"`
+	Apps   []AppStatus `json:"apps"`
+}
+
+// webhookTarget is a single outbound webhook destination. Each target has
+// its own delivery queue and drain goroutine, so a slow or unreachable
+// target retrying with backoff can't hold up deliveries to any other
+// target.
+type webhookTarget struct {
+	url     string
+	enabled atomic.Bool
+	queue   chan []byte
+}
+
+// WebhookNotifier fans outbound webhook POSTs to a set of target URLs,
+// HMAC-signing each body and retrying failed deliveries with exponential
+// backoff via a bounded per-target queue, so a slow or unreachable target
+// can't block the rest of the notification pipeline.
+type WebhookNotifier struct {
+	secret     string
+	maxRetries int
+
+	mu      sync.Mutex
+	targets []*webhookTarget
+
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a WebhookNotifier posting to the given target
+// URLs, all enabled by default, signing bodies with secret if non-empty.
+func NewWebhookNotifier(urls []string, secret string) *WebhookNotifier {
+	n := &WebhookNotifier{
+		secret:     secret,
+		maxRetries: 5,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, url := range urls {
+		n.targets = append(n.targets, n.newTarget(url))
+	}
+	return n
+}
+
+// newTarget registers a target with its own delivery queue and starts its
+// drain goroutine.
+func (n *WebhookNotifier) newTarget(url string) *webhookTarget {
+	target := &webhookTarget{url: url, queue: make(chan []byte, webhookQueueSize)}
+	target.enabled.Store(true)
+	go n.drainQueue(target)
+	return target
+}
+
+// SetTargetEnabled enables or disables delivery to a registered target URL.
+// enabled is an atomic.Bool rather than a plain field guarded by n.mu,
+// since NotifyDetailed reads it well after releasing n.mu (it only holds
+// the lock long enough to snapshot the target slice). Not yet wired to any
+// CLI flag or HTTP endpoint; currently only reachable programmatically
+// (see webhook_test.go) until an operator-facing control surface exists.
+func (n *WebhookNotifier) SetTargetEnabled(url string, enabled bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, target := range n.targets {
+		if target.url == url {
+			target.enabled.Store(enabled)
+		}
+	}
+}
+
+// Notify implements Notifier by posting the flattened status with no
+// per-app detail. Prefer NotifyDetailed when app detail is available.
+func (n *WebhookNotifier) Notify(status string) error {
+	return n.NotifyDetailed(status, nil)
+}
+
+func (n *WebhookNotifier) Name() string { return "webhook" }
+
+// NotifyDetailed enqueues a signed webhook POST to every enabled target.
+func (n *WebhookNotifier) NotifyDetailed(status string, apps []AppStatus) error {
+	body, err := json.Marshal(webhookPayload{Status: status, Apps: apps})
+	if err != nil {
+		return fmt.Errorf("webhook: marshaling payload: %w", err)
+	}
+
+	n.mu.Lock()
+	targets := make([]*webhookTarget, len(n.targets))
+	copy(targets, n.targets)
+	n.mu.Unlock()
+
+	for _, target := range targets {
+		if !target.enabled.Load() {
+			continue
+		}
+		select {
+		case target.queue <- body:
+		default:
+			log.Println("webhook queue full, dropping delivery to", target.url)
+		}
+	}
+	return nil
+}
+
+func (n *WebhookNotifier) drainQueue(target *webhookTarget) {
+	for body := range target.queue {
+		if err := n.deliver(target, body); err != nil {
+			log.Println("webhook delivery to", target.url, "failed permanently:", err)
+		}
+	}
+}
+
+func (n *WebhookNotifier) deliver(target *webhookTarget, body []byte) error {
+	var err error
+	for attempt := 0; attempt <= n.maxRetries; attempt++ {
+		if err = n.post(target.url, body); err == nil {
+			return nil
+		}
+		if attempt < n.maxRetries {
+			time.Sleep(time.Duration(1<<uint(attempt)) * time.Second)
+		}
+	}
+	return err
+}
+
+func (n *WebhookNotifier) post(url string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != "" {
+		req.Header.Set("X-Argos-Signature", signWebhookPayload(n.secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func signWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayloadDeterministic(t *testing.T) {
+	body := []byte(`{"status":"@"}`)
+
+	got := signWebhookPayload("s3cr3t", body)
+
+	mac := hmac.New(sha256.New, []byte("s3cr3t"))
+	mac.Write(body)
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	if got != want {
+		t.Errorf("signWebhookPayload() = %q, want %q", got, want)
+	}
+
+	if signWebhookPayload("other", body) == got {
+		t.Error("signWebhookPayload() with a different secret produced the same signature")
+	}
+}
+
+// TestWebhookSlowTargetDoesNotBlockOthers verifies that a target stuck in
+// retry backoff can't delay delivery to a healthy target (the defect fixed
+// alongside giving each target its own queue).
+func TestWebhookSlowTargetDoesNotBlockOthers(t *testing.T) {
+	var mu sync.Mutex
+	delivered := map[string]bool{}
+
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		delivered["good"] = true
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer good.Close()
+
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer bad.Close()
+
+	notifier := NewWebhookNotifier([]string{bad.URL, good.URL}, "")
+	notifier.maxRetries = 0
+
+	if err := notifier.NotifyDetailed("@", nil); err != nil {
+		t.Fatalf("NotifyDetailed() error: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		ok := delivered["good"]
+		mu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("delivery to the healthy target never completed")
+}
+
+func TestWebhookNotifyDetailedSignsBody(t *testing.T) {
+	received := make(chan *http.Request, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		received <- r
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier([]string{server.URL}, "s3cr3t")
+	notifier.maxRetries = 0
+
+	if err := notifier.NotifyDetailed("@", []AppStatus{{App: "hobsons.slack.com", Label: "1"}}); err != nil {
+		t.Fatalf("NotifyDetailed() error: %v", err)
+	}
+
+	select {
+	case r := <-received:
+		if r.Header.Get("X-Argos-Signature") == "" {
+			t.Error("request missing X-Argos-Signature header")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("target never received a request")
+	}
+}
+
+func TestWebhookSetTargetEnabledSkipsDelivery(t *testing.T) {
+	var requests int
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier([]string{server.URL}, "")
+	notifier.maxRetries = 0
+	notifier.SetTargetEnabled(server.URL, false)
+
+	if err := notifier.NotifyDetailed("@", nil); err != nil {
+		t.Fatalf("NotifyDetailed() error: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 0 {
+		t.Errorf("got %d requests to a disabled target, want 0", requests)
+	}
+}